@@ -0,0 +1,146 @@
+package deb
+
+import (
+	"io"
+	"strings"
+)
+
+// Package is a single entry parsed from a Packages index: the raw Stanza
+// plus the fields callers need most often pulled out for convenience.
+type Package struct {
+	*Stanza
+	Name         string
+	Architecture string
+	Version      string
+	Filename     string
+	SHA256       string
+	Provides     []string
+}
+
+func newPackage(s *Stanza) *Package {
+	p := &Package{
+		Stanza:       s,
+		Name:         s.Get("Package"),
+		Architecture: s.Get("Architecture"),
+		Version:      s.Get("Version"),
+		Filename:     s.Get("Filename"),
+		SHA256:       s.Get("SHA256"),
+	}
+	if provides := s.Get("Provides"); provides != "" {
+		for _, part := range strings.Split(provides, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				p.Provides = append(p.Provides, part)
+			}
+		}
+	}
+	return p
+}
+
+// PackageKey uniquely identifies a package within a PackageList.
+type PackageKey struct {
+	Name         string
+	Architecture string
+	Version      string
+}
+
+func (p *Package) key() PackageKey {
+	return PackageKey{Name: p.Name, Architecture: p.Architecture, Version: p.Version}
+}
+
+// PackageList is an in-memory catalog of packages keyed by
+// (Package, Architecture, Version), with secondary indexes by name and by
+// what they declare in Provides.
+type PackageList struct {
+	packages   map[PackageKey]*Package
+	byName     map[string][]*Package
+	byProvides map[string][]*Package
+}
+
+// NewPackageList returns an empty PackageList ready for Add.
+func NewPackageList() *PackageList {
+	return &PackageList{
+		packages:   map[PackageKey]*Package{},
+		byName:     map[string][]*Package{},
+		byProvides: map[string][]*Package{},
+	}
+}
+
+// Add indexes p by its key, name, and provides.
+func (l *PackageList) Add(p *Package) {
+	l.packages[p.key()] = p
+	l.byName[p.Name] = append(l.byName[p.Name], p)
+	for _, provided := range p.Provides {
+		l.byProvides[provided] = append(l.byProvides[provided], p)
+	}
+}
+
+// Get looks up a single package by its full key.
+func (l *PackageList) Get(key PackageKey) (*Package, bool) {
+	p, ok := l.packages[key]
+	return p, ok
+}
+
+// ByName returns every known version/architecture of name.
+func (l *PackageList) ByName(name string) []*Package {
+	return l.byName[name]
+}
+
+// ByProvides returns every package that declares it provides name.
+func (l *PackageList) ByProvides(name string) []*Package {
+	return l.byProvides[name]
+}
+
+// All returns every package in the list, in no particular order.
+func (l *PackageList) All() []*Package {
+	out := make([]*Package, 0, len(l.packages))
+	for _, p := range l.packages {
+		out = append(out, p)
+	}
+	return out
+}
+
+// Search returns every package whose name, or one of its Provides entries,
+// contains the query substring (case-insensitive).
+func (l *PackageList) Search(query string) []*Package {
+	query = strings.ToLower(query)
+	seen := map[PackageKey]bool{}
+	var results []*Package
+
+	add := func(p *Package) {
+		if seen[p.key()] {
+			return
+		}
+		seen[p.key()] = true
+		results = append(results, p)
+	}
+
+	for name, pkgs := range l.byName {
+		if strings.Contains(strings.ToLower(name), query) {
+			for _, p := range pkgs {
+				add(p)
+			}
+		}
+	}
+	for provided, pkgs := range l.byProvides {
+		if strings.Contains(strings.ToLower(provided), query) {
+			for _, p := range pkgs {
+				add(p)
+			}
+		}
+	}
+	return results
+}
+
+// ParsePackages parses an extracted Packages index file into a PackageList.
+func ParsePackages(r io.Reader) (*PackageList, error) {
+	stanzas, err := ReadStanzas(r)
+	if err != nil {
+		return nil, err
+	}
+
+	list := NewPackageList()
+	for _, s := range stanzas {
+		list.Add(newPackage(s))
+	}
+	return list, nil
+}
@@ -0,0 +1,58 @@
+package deb
+
+import (
+	"strings"
+	"testing"
+)
+
+const samplePackages = `Package: curl
+Version: 7.68.0-1ubuntu2
+Architecture: amd64
+Provides: http-client
+Filename: pool/main/c/curl/curl_7.68.0-1ubuntu2_amd64.deb
+SHA256: 1111111111111111111111111111111111111111111111111111111111111111
+Description: command line tool for transferring data
+ curl is a tool for transferring data from or to a server.
+ .
+ It supports many protocols.
+
+Package: wget
+Version: 1.20.3-1ubuntu2
+Architecture: amd64
+Filename: pool/main/w/wget/wget_1.20.3-1ubuntu2_amd64.deb
+SHA256: 2222222222222222222222222222222222222222222222222222222222222222
+`
+
+func TestParsePackages(t *testing.T) {
+	list, err := ParsePackages(strings.NewReader(samplePackages))
+	if err != nil {
+		t.Fatalf("ParsePackages() error = %v", err)
+	}
+
+	pkgs := list.ByName("curl")
+	if len(pkgs) != 1 {
+		t.Fatalf("ByName(curl) = %d packages, want 1", len(pkgs))
+	}
+	curl := pkgs[0]
+	if curl.Version != "7.68.0-1ubuntu2" {
+		t.Errorf("Version = %q", curl.Version)
+	}
+	if curl.Filename != "pool/main/c/curl/curl_7.68.0-1ubuntu2_amd64.deb" {
+		t.Errorf("Filename = %q", curl.Filename)
+	}
+	if !strings.Contains(curl.Get("Description"), "It supports many protocols.") {
+		t.Errorf("Description did not preserve continuation lines: %q", curl.Get("Description"))
+	}
+
+	if provides := list.ByProvides("http-client"); len(provides) != 1 || provides[0].Name != "curl" {
+		t.Errorf("ByProvides(http-client) = %v, want [curl]", provides)
+	}
+
+	if got := list.Search("wget"); len(got) != 1 || got[0].Name != "wget" {
+		t.Errorf("Search(wget) = %v, want [wget]", got)
+	}
+
+	if len(list.All()) != 2 {
+		t.Errorf("All() = %d packages, want 2", len(list.All()))
+	}
+}
@@ -0,0 +1,92 @@
+// Package deb parses the Packages indexes apt repositories publish into a
+// structured, queryable catalog.
+package deb
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Stanza is a single RFC822-style (deb822) key/value block, as used by
+// Packages index entries. Field order is preserved so a Stanza can be
+// inspected the same way the original text reads, top to bottom.
+type Stanza struct {
+	keys   []string
+	values map[string]string
+}
+
+// NewStanza returns an empty Stanza ready for Set.
+func NewStanza() *Stanza {
+	return &Stanza{values: map[string]string{}}
+}
+
+// Get returns the value for key, or "" if it is not present.
+func (s *Stanza) Get(key string) string {
+	return s.values[key]
+}
+
+// Set stores value for key, recording key's position the first time it is
+// set.
+func (s *Stanza) Set(key, value string) {
+	if _, ok := s.values[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.values[key] = value
+}
+
+// Keys returns the stanza's field names in the order they were first set.
+func (s *Stanza) Keys() []string {
+	return append([]string(nil), s.keys...)
+}
+
+// ReadStanzas reads every deb822 stanza from r (stanzas are separated by a
+// blank line), joining continuation lines - those starting with whitespace,
+// as used by the multi-line SHA256 and Description fields - onto the
+// previous field's value. A continuation line of " ." represents a blank
+// line within the field, per the Description convention.
+func ReadStanzas(r io.Reader) ([]*Stanza, error) {
+	var stanzas []*Stanza
+	cur := NewStanza()
+	var lastKey string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if len(cur.keys) > 0 {
+				stanzas = append(stanzas, cur)
+				cur = NewStanza()
+				lastKey = ""
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if lastKey == "" {
+				continue
+			}
+			cont := strings.TrimPrefix(line, " ")
+			if cont == "." {
+				cont = ""
+			}
+			cur.Set(lastKey, cur.Get(lastKey)+"\n"+cont)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		cur.Set(key, strings.TrimSpace(value))
+		lastKey = key
+	}
+
+	if len(cur.keys) > 0 {
+		stanzas = append(stanzas, cur)
+	}
+	return stanzas, scanner.Err()
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Downloader fetches remote files onto the local filesystem, verifying
+// their contents against a known checksum, in the spirit of aptly's
+// downloader. Implementations must be safe for concurrent use.
+type Downloader interface {
+	// GetLength returns the Content-Length reported for url without
+	// downloading the body.
+	GetLength(url string) (int64, error)
+	// DownloadWithChecksum returns immediately, without touching the
+	// network, if dest already matches expected - so re-running Update
+	// skips re-downloading Packages.gz/.deb files that haven't changed on
+	// the server. Otherwise it downloads url to dest, resuming a partial
+	// "<dest>.part" if one exists and the server answers its Range request
+	// with 206 Partial Content (any 200 reply restarts the file), retrying
+	// with exponential backoff after any failed attempt (network error,
+	// unexpected status, or checksum mismatch) up to maxTries times, and
+	// verifying the result against expected (whichever
+	// of its MD5/SHA1/SHA256 fields are set) before renaming it into place.
+	// Bytes read from the response are teed into bar as they arrive, so
+	// progress is visible during the transfer; bar may be nil.
+	DownloadWithChecksum(url, dest string, expected ChecksumInfo, maxTries int, bar ProgressBar) error
+}
+
+// httpDownloader is the default Downloader, backed by net/http.
+type httpDownloader struct {
+	Client  *http.Client
+	InfoLog *log.Logger
+	ErrLog  *log.Logger
+}
+
+// NewDownloader returns the default http-backed Downloader.
+func NewDownloader(infoLog, errLog *log.Logger) Downloader {
+	return &httpDownloader{Client: http.DefaultClient, InfoLog: infoLog, ErrLog: errLog}
+}
+
+func (d *httpDownloader) GetLength(url string) (int64, error) {
+	resp, err := d.Client.Head(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+	return resp.ContentLength, nil
+}
+
+func (d *httpDownloader) DownloadWithChecksum(url, dest string, expected ChecksumInfo, maxTries int, bar ProgressBar) error {
+	if maxTries <= 0 {
+		maxTries = 1
+	}
+
+	if verifyChecksumInfo(dest, expected) == nil {
+		d.InfoLog.Printf("%s already matches the expected checksum, skipping download", dest)
+		return nil
+	}
+
+	partPath := dest + ".part"
+
+	var lastErr error
+	for attempt := 1; attempt <= maxTries; attempt++ {
+		if err := d.downloadOnce(url, partPath, expected, bar); err != nil {
+			lastErr = err
+			d.ErrLog.Printf("download attempt %d/%d for %s failed: %s", attempt, maxTries, url, err)
+			// Only a checksum mismatch invalidates the bytes already on
+			// disk; a network error leaves partPath as-is so the next
+			// attempt can resume from it via Range.
+			var mismatch *checksumMismatchError
+			if errors.As(err, &mismatch) {
+				os.Remove(partPath)
+			}
+			time.Sleep(downloadBackoff(attempt))
+			continue
+		}
+
+		if err := os.Rename(partPath, dest); err != nil {
+			return err
+		}
+		d.InfoLog.Printf("downloaded %s to %s", url, dest)
+		return nil
+	}
+	return fmt.Errorf("failed to download %s after %d attempts: %w", url, maxTries, lastErr)
+}
+
+// downloadOnce streams url into partPath, resuming from partPath's current
+// size via a Range request if it already exists and the server accepts it,
+// hashing the bytes as they're written so the whole file never needs a
+// second read to verify it against expected. Bytes read from the response
+// are also teed into bar, if non-nil, as they arrive.
+func (d *httpDownloader) downloadOnce(url, partPath string, expected ChecksumInfo, bar ProgressBar) error {
+	var offset int64
+	flags := os.O_CREATE | os.O_WRONLY
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+		flags |= os.O_APPEND
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// Resuming from offset.
+	case http.StatusOK:
+		// Server ignored our Range header (or there was nothing to resume);
+		// start the file over.
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		offset = 0
+	default:
+		return fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher, err := newResumableChecksumHasher(partPath, offset)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader = resp.Body
+	if bar != nil {
+		body = io.TeeReader(body, bar)
+	}
+	body = io.TeeReader(body, hasher)
+	if _, err := io.Copy(f, body); err != nil {
+		return err
+	}
+
+	return hasher.verify(partPath, expected)
+}
+
+// downloadBackoff returns an exponential backoff duration for a given retry
+// attempt (1-indexed), capped at 30 seconds.
+func downloadBackoff(attempt int) time.Duration {
+	backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+	if backoff > 30*time.Second {
+		backoff = 30 * time.Second
+	}
+	return backoff
+}
+
+// verifyChecksumInfo hashes the file at path in a single pass and compares
+// it against whichever of expected's MD5/SHA1/SHA256 fields are non-empty.
+// DownloadWithChecksum uses it both to skip a download when dest already
+// matches expected, and (via checksumHasher, which shares its comparison
+// logic) to verify a freshly streamed file without a second read.
+func verifyChecksumInfo(path string, expected ChecksumInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	md5h, sha1h, sha256h := md5.New(), sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(md5h, sha1h, sha256h), f); err != nil {
+		return err
+	}
+
+	return compareChecksums(path, expected, md5h, sha1h, sha256h)
+}
+
+func compareChecksums(path string, expected ChecksumInfo, md5h, sha1h, sha256h hash.Hash) error {
+	if expected.SHA256 != "" {
+		if got := hex.EncodeToString(sha256h.Sum(nil)); got != expected.SHA256 {
+			return &checksumMismatchError{fmt.Sprintf("sha256 mismatch for %s: got %s, want %s", path, got, expected.SHA256)}
+		}
+	}
+	if expected.SHA1 != "" {
+		if got := hex.EncodeToString(sha1h.Sum(nil)); got != expected.SHA1 {
+			return &checksumMismatchError{fmt.Sprintf("sha1 mismatch for %s: got %s, want %s", path, got, expected.SHA1)}
+		}
+	}
+	if expected.MD5 != "" {
+		if got := hex.EncodeToString(md5h.Sum(nil)); got != expected.MD5 {
+			return &checksumMismatchError{fmt.Sprintf("md5 mismatch for %s: got %s, want %s", path, got, expected.MD5)}
+		}
+	}
+	return nil
+}
+
+// checksumMismatchError distinguishes a failed checksum comparison from a
+// network error, so DownloadWithChecksum knows a mismatch means the bytes
+// on disk are bad (and partPath must be discarded) while a network error
+// means they're still good to resume from.
+type checksumMismatchError struct{ reason string }
+
+func (e *checksumMismatchError) Error() string { return e.reason }
+
+// checksumHasher hashes bytes as they're written to partPath, so
+// downloadOnce can verify the result against an expected ChecksumInfo
+// without a second read of the whole file. It implements io.Writer so it
+// can sit in the download's TeeReader chain alongside the progress bar.
+type checksumHasher struct {
+	md5, sha1, sha256 hash.Hash
+}
+
+// newResumableChecksumHasher returns a checksumHasher primed with the
+// hashes of partPath's first offset bytes, so a resumed download's hash
+// covers the whole file rather than just the newly streamed suffix.
+func newResumableChecksumHasher(partPath string, offset int64) (*checksumHasher, error) {
+	h := &checksumHasher{md5: md5.New(), sha1: sha1.New(), sha256: sha256.New()}
+	if offset == 0 {
+		return h, nil
+	}
+
+	f, err := os.Open(partPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(io.MultiWriter(h.md5, h.sha1, h.sha256), f, offset); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *checksumHasher) Write(p []byte) (int, error) {
+	io.MultiWriter(h.md5, h.sha1, h.sha256).Write(p)
+	return len(p), nil
+}
+
+func (h *checksumHasher) verify(path string, expected ChecksumInfo) error {
+	return compareChecksums(path, expected, h.md5, h.sha1, h.sha256)
+}
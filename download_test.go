@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadBackoffCapsAt30Seconds(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 30 * time.Second}, // 32s uncapped
+		{10, 30 * time.Second},
+	}
+	for _, c := range cases {
+		if got := downloadBackoff(c.attempt); got != c.want {
+			t.Errorf("downloadBackoff(%d) = %s, want %s", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestVerifyChecksumInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// sha256("hello world")
+	const wantSHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+	if err := verifyChecksumInfo(path, ChecksumInfo{SHA256: wantSHA256}); err != nil {
+		t.Errorf("verifyChecksumInfo() with a correct checksum error = %v", err)
+	}
+	if err := verifyChecksumInfo(path, ChecksumInfo{SHA256: "0000000000000000000000000000000000000000000000000000000000000000"}); err == nil {
+		t.Error("verifyChecksumInfo() with a mismatched checksum unexpectedly succeeded")
+	}
+}
+
+func TestHTTPDownloaderDownloadWithChecksum(t *testing.T) {
+	const body = "the quick brown fox jumps over the lazy dog"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "fox.txt", time.Time{}, strings.NewReader(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "fox.txt")
+
+	var logBuf bytes.Buffer
+	logger := log.New(&logBuf, "", 0)
+	downloader := NewDownloader(logger, logger)
+
+	// sha256("the quick brown fox jumps over the lazy dog")
+	const wantSHA256 = "05c6e08f1d9fdafa03147fcb8f82f124c76d2f70e3d989dc8aadb5e7d7450bec"
+
+	if err := downloader.DownloadWithChecksum(server.URL, dest, ChecksumInfo{SHA256: wantSHA256}, 3, nil); err != nil {
+		t.Fatalf("DownloadWithChecksum() error = %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("downloaded contents = %q, want %q", got, body)
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.part to be cleaned up after a successful download", dest)
+	}
+}
+
+func TestHTTPDownloaderDownloadWithChecksumSkipsUnchangedDest(t *testing.T) {
+	calls := 0
+	const body = "the quick brown fox jumps over the lazy dog"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.ServeContent(w, r, "fox.txt", time.Time{}, strings.NewReader(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "fox.txt")
+	const wantSHA256 = "05c6e08f1d9fdafa03147fcb8f82f124c76d2f70e3d989dc8aadb5e7d7450bec"
+	if err := os.WriteFile(dest, []byte(body), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var logBuf bytes.Buffer
+	logger := log.New(&logBuf, "", 0)
+	downloader := NewDownloader(logger, logger)
+
+	if err := downloader.DownloadWithChecksum(server.URL, dest, ChecksumInfo{SHA256: wantSHA256}, 3, nil); err != nil {
+		t.Fatalf("DownloadWithChecksum() error = %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("server was hit %d times, want 0: an already-matching dest should short-circuit the download", calls)
+	}
+}
+
+func TestHTTPDownloaderDownloadWithChecksumResumesPartial(t *testing.T) {
+	const body = "the quick brown fox jumps over the lazy dog"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "fox.txt", time.Time{}, strings.NewReader(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "fox.txt")
+	// Simulate a previous attempt that only got partway through.
+	if err := os.WriteFile(dest+".part", []byte(body[:10]), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var logBuf bytes.Buffer
+	logger := log.New(&logBuf, "", 0)
+	downloader := NewDownloader(logger, logger)
+
+	const wantSHA256 = "05c6e08f1d9fdafa03147fcb8f82f124c76d2f70e3d989dc8aadb5e7d7450bec"
+	if err := downloader.DownloadWithChecksum(server.URL, dest, ChecksumInfo{SHA256: wantSHA256}, 3, nil); err != nil {
+		t.Fatalf("DownloadWithChecksum() error = %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("downloaded contents = %q, want %q (resume should yield the full file, hashed correctly)", got, body)
+	}
+}
+
+func TestHTTPDownloaderDownloadWithChecksumFailsOnMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "fox.txt", time.Time{}, strings.NewReader("not what we expected"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "fox.txt")
+
+	var logBuf bytes.Buffer
+	logger := log.New(&logBuf, "", 0)
+	downloader := NewDownloader(logger, logger)
+
+	err := downloader.DownloadWithChecksum(server.URL, dest, ChecksumInfo{SHA256: strings.Repeat("0", 64)}, 1, nil)
+	if err == nil {
+		t.Fatal("DownloadWithChecksum() with a mismatched checksum unexpectedly succeeded")
+	}
+	if _, statErr := os.Stat(dest); !os.IsNotExist(statErr) {
+		t.Error("dest should not exist after every attempt fails checksum verification")
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,6 +15,9 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/tylerchambers/aptf/deb"
 )
 
 // AptSource represents a single apt source.
@@ -21,15 +26,44 @@ type AptSource struct {
 	URI        string
 	Suite      string
 	Components []string
+	// Architectures restricts this source to specific binary architectures
+	// (e.g. "amd64", "arm64"). Empty means the historical amd64-only default.
+	Architectures []string
+	// SignedBy optionally names the keyring file this source's Release must
+	// be signed by, as in deb822's Signed-By or the one-line signed-by=
+	// option.
+	SignedBy string
 }
 
-// SourceFromString validates and parses an apt source string.
+// SourceFromString validates and parses an apt source string in the
+// classic one-line format, including an optional leading "[key=value ...]"
+// options block (e.g. "[arch=amd64,armhf signed-by=/path/to.gpg]").
 func SourceFromString(s string, uuidProvider func() uuid.UUID) (*AptSource, error) {
-	entry := strings.Fields(s)
-	// We do not support inline options.
-	if strings.ContainsAny(s, "[]") {
-		return nil, fmt.Errorf("inline options are not supported")
+	line := s
+
+	var architectures []string
+	var signedBy string
+	if start := strings.Index(line, "["); start != -1 {
+		end := strings.Index(line, "]")
+		if end < start {
+			return nil, fmt.Errorf("unterminated inline options in source string: %s", s)
+		}
+		for _, opt := range strings.Fields(line[start+1 : end]) {
+			key, value, ok := strings.Cut(opt, "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "arch", "architectures":
+				architectures = strings.Split(value, ",")
+			case "signed-by":
+				signedBy = value
+			}
+		}
+		line = line[:start] + line[end+1:]
 	}
+
+	entry := strings.Fields(line)
 	// We need at least 4 fields: name, uri, suite, component
 	if len(entry) < 4 {
 		return nil, fmt.Errorf("invalid source string: %s", s)
@@ -47,10 +81,12 @@ func SourceFromString(s string, uuidProvider func() uuid.UUID) (*AptSource, erro
 	entry[1] = strings.TrimSuffix(entry[1], "/")
 
 	return &AptSource{
-		ID:         uuidProvider(),
-		URI:        entry[1],
-		Suite:      entry[2],
-		Components: entry[3:],
+		ID:            uuidProvider(),
+		URI:           entry[1],
+		Suite:         entry[2],
+		Components:    entry[3:],
+		Architectures: architectures,
+		SignedBy:      signedBy,
 	}, nil
 }
 
@@ -88,24 +124,56 @@ func (a *AptSourceRegistry) RmSourceByID(id uuid.UUID) {
 	}
 }
 
-// GenerateRepoURIs generates a list of repo URIs from the registry source entries.
+// GenerateRepoURIs generates a list of repo URIs from the registry source
+// entries: one per (component, architecture) pair. A source with no
+// Architectures set produces one URI per component, matching the
+// historical amd64-only behavior.
 func (a *AptSourceRegistry) GenerateRepoURIs() {
 	// Empty the list.
 	a.RepoURIs = []string{}
 
 	for _, s := range a.Sources {
-		// for each component, generate a repo URI
-		// URI format: s.URI + "/" + "dists" + "/" + s.Suite + "/" + s.Component + "/"
-		// We only support amd64 binaries for now.
+		archs := s.Architectures
+		if len(archs) == 0 {
+			archs = []string{""}
+		}
+		// URI format: s.URI + "/" + "dists" + "/" + s.Suite + "/" + s.Component[+ "/binary-" + arch]
 		for _, c := range s.Components {
-			a.RepoURIs = append(a.RepoURIs, fmt.Sprintf("%s/%s/%s/%s", s.URI, "dists", s.Suite, c))
+			base := fmt.Sprintf("%s/%s/%s/%s", s.URI, "dists", s.Suite, c)
+			for _, arch := range archs {
+				if arch == "" {
+					a.RepoURIs = append(a.RepoURIs, base)
+					continue
+				}
+				a.RepoURIs = append(a.RepoURIs, fmt.Sprintf("%s/binary-%s", base, arch))
+			}
 		}
 	}
 }
 
-// ParseSourcesList takes a file path and parses the file as an apt sources list.
-// For each line, it validates and parses the source string.
+// ParseSourcesList parses path as an apt sources file. A directory
+// (conventionally sources.list.d) has every *.list and *.sources file
+// inside it parsed and merged into a single registry; a file ending in
+// ".sources" is parsed as deb822, anything else as the legacy
+// one-line-per-source format.
 func ParseSourcesList(path string) (*AptSourceRegistry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return parseSourcesListDir(path)
+	}
+	if strings.HasSuffix(path, ".sources") {
+		return ParseDEB822Sources(path)
+	}
+	return parseOneLineSourcesList(path)
+}
+
+// parseOneLineSourcesList parses a classic sources.list file, one source
+// string per line.
+func parseOneLineSourcesList(path string) (*AptSourceRegistry, error) {
 	// Open the file
 	f, err := os.Open(path)
 	if err != nil {
@@ -119,8 +187,12 @@ func ParseSourcesList(path string) (*AptSourceRegistry, error) {
 	// Read the file line by line
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
 		// Parse the source string
-		s, err := SourceFromString(scanner.Text(), func() uuid.UUID { return uuid.New() })
+		s, err := SourceFromString(line, func() uuid.UUID { return uuid.New() })
 		if err != nil {
 			return nil, err
 		}
@@ -139,6 +211,34 @@ func ParseSourcesList(path string) (*AptSourceRegistry, error) {
 	return r, nil
 }
 
+// parseSourcesListDir parses and merges every *.list and *.sources file in
+// dir, as apt does for /etc/apt/sources.list.d.
+func parseSourcesListDir(dir string) (*AptSourceRegistry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &AptSourceRegistry{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if !strings.HasSuffix(name, ".list") && !strings.HasSuffix(name, ".sources") {
+			continue
+		}
+		r, err := ParseSourcesList(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		merged.AddSources(r.Sources)
+	}
+
+	merged.GenerateRepoURIs()
+	return merged, nil
+}
+
 type DownloaderMessage struct {
 	Message string
 	Err     error
@@ -159,6 +259,12 @@ func NewDownloadManager(workers int) *DownloadManager {
 type DownlaodRequest struct {
 	URI         string
 	Destination string
+	// Expected is the checksum the downloaded file must match. A zero value
+	// (all fields empty) skips verification.
+	Expected ChecksumInfo
+	// MaxTries is how many attempts DownloadWithChecksum gets before giving
+	// up. Defaults to 3 if unset.
+	MaxTries int
 }
 
 func URLtoFilename(url string) string {
@@ -174,36 +280,23 @@ func URLtoFilename(url string) string {
 	return url
 }
 
-func DownloadWorker(reqs <-chan DownlaodRequest, messages chan<- DownloaderMessage) {
+func DownloadWorker(downloader Downloader, progress Progress, reqs <-chan DownlaodRequest, messages chan<- DownloaderMessage) {
 	for r := range reqs {
-		// Download the file
-		resp, err := http.Get(r.URI)
-		if err != nil {
-			messages <- DownloaderMessage{Message: fmt.Sprintf("failed to download %s", r.URI), Err: err}
-			continue
+		maxTries := r.MaxTries
+		if maxTries <= 0 {
+			maxTries = 3
 		}
 
-		// Create a new file
-		f, err := os.Create(r.Destination)
-		if err != nil {
-			messages <- DownloaderMessage{Message: fmt.Sprintf("failed to create file %s", r.Destination), Err: err}
-			continue
-		}
+		length, _ := downloader.GetLength(r.URI)
+		bar := progress.AddBar(length)
+		bar.SetName(r.URI)
 
-		// Write the file
-		_, err = io.Copy(f, resp.Body)
+		err := downloader.DownloadWithChecksum(r.URI, r.Destination, r.Expected, maxTries, bar)
+		bar.Finish()
 		if err != nil {
-			if resp.Body != nil {
-				resp.Body.Close()
-			}
-			if f != nil {
-				f.Close()
-			}
-			messages <- DownloaderMessage{Message: fmt.Sprintf("failed to write file %s", r.Destination), Err: err}
+			messages <- DownloaderMessage{Message: fmt.Sprintf("failed to download %s", r.URI), Err: err}
 			continue
 		}
-		resp.Body.Close()
-		f.Close()
 		messages <- DownloaderMessage{
 			Message: fmt.Sprintf("downloaded %s to %s", r.URI, r.Destination),
 			Err:     nil,
@@ -211,7 +304,10 @@ func DownloadWorker(reqs <-chan DownlaodRequest, messages chan<- DownloaderMessa
 	}
 }
 
-func (d *DownloadManager) Download(requests []DownlaodRequest, infoLog, errLog *log.Logger) {
+func (d *DownloadManager) Download(requests []DownlaodRequest, progress Progress) {
+	logger := progressLogger(progress)
+	downloader := NewDownloader(logger, logger)
+
 	// Create a channel for the requests
 	reqs := make(chan DownlaodRequest, len(requests))
 
@@ -220,7 +316,7 @@ func (d *DownloadManager) Download(requests []DownlaodRequest, infoLog, errLog *
 
 	// Create a pool of workers
 	for i := 0; i < d.Workers; i++ {
-		go DownloadWorker(reqs, messages)
+		go DownloadWorker(downloader, progress, reqs, messages)
 	}
 	// Send the requests to the workers
 	for _, request := range requests {
@@ -231,9 +327,9 @@ func (d *DownloadManager) Download(requests []DownlaodRequest, infoLog, errLog *
 	for i := 0; i < len(requests); i++ {
 		msg := <-messages
 		if msg.Err != nil {
-			errLog.Printf("%s: %s", msg.Message, msg.Err)
+			progress.Printf("%s: %s", msg.Message, msg.Err)
 		} else {
-			infoLog.Printf("%s", msg.Message)
+			progress.Printf("%s", msg.Message)
 		}
 	}
 }
@@ -242,46 +338,96 @@ type AptCLient struct {
 	AptfDir           string
 	AptSourceRegistry *AptSourceRegistry
 	DownloadManager   *DownloadManager
+	TrustStore        *TrustStore
+	Progress          Progress
 	InfoLog           *log.Logger
 	ErrLog            *log.Logger
+
+	// Catalog is the in-memory index of every package across all sources,
+	// built by LoadCatalog. Nil until LoadCatalog has run.
+	Catalog *deb.PackageList
+	// catalogOrigins remembers which AptSource each package in Catalog was
+	// parsed from, so Download can resolve its Filename against the right
+	// base URI.
+	catalogOrigins map[deb.PackageKey]*AptSource
+
+	// MirrorOptions, if non-nil, makes Update call
+	// AptSourceRegistry.SelectFastestMirrors with these options before
+	// building its download requests, rewriting each source's URI to its
+	// fastest mirror. Nil (the default) skips mirror selection and leaves
+	// every source's configured URI untouched.
+	MirrorOptions *MirrorSelectOptions
 }
 
-func (c *AptCLient) InitTrustDir() error {
-	trustDir := filepath.Join(c.AptfDir, "trust")
-	err := makeDirectoryIfNotExists(trustDir)
-	if err != nil {
-		c.ErrLog.Printf("failed to create trust directory %s: %s", trustDir, err)
-		return err
-	}
+// EnableFastestMirrors turns on mirror selection for future calls to
+// Update, probing candidates and caching the choice under opts.CacheDir
+// (nothing is cached if it is empty). opts is used as given.
+func (c *AptCLient) EnableFastestMirrors(opts MirrorSelectOptions) {
+	c.MirrorOptions = &opts
+}
 
-	// PGP Keys we trust
-	keysDir := filepath.Join(trustDir, "keys")
-	err = makeDirectoryIfNotExists(keysDir)
-	if err != nil {
-		c.ErrLog.Printf("failed to create keys directory %s: %s", keysDir, err)
-		return err
-	}
+// releaseSourceKey returns the stable identifier used to track a source's
+// trusted Release fingerprint across runs.
+func releaseSourceKey(s *AptSource) string {
+	return fmt.Sprintf("%s|%s", s.URI, s.Suite)
+}
 
-	// Hashes of files we trust
-	hashesDir := filepath.Join(trustDir, "hashes")
-	err = makeDirectoryIfNotExists(hashesDir)
+// fetchReleaseAndVerify downloads the InRelease (or, failing that, Release
+// plus Release.gpg) file for source, checks its PGP signature against the
+// keyring source.SignedBy scopes it to (or c.TrustStore's whole keyring, if
+// source declares no signed-by), and rejects it if it is older than the
+// last Release this source was trusted for.
+func (c *AptCLient) fetchReleaseAndVerify(source *AptSource) (*ReleaseInfo, error) {
+	keyring, err := c.TrustStore.KeyringFor(source)
 	if err != nil {
-		c.ErrLog.Printf("failed to create hashes directory %s: %s", hashesDir, err)
-		return err
+		return nil, err
 	}
 
-	// create a file in the hashes directory called "releases" if it doesn't exist
-	releasesFile := filepath.Join(hashesDir, "releases")
-	if _, err := os.Stat(releasesFile); os.IsNotExist(err) {
-		f, err := os.Create(releasesFile)
+	base := fmt.Sprintf("%s/dists/%s", source.URI, source.Suite)
+
+	var rel *ReleaseInfo
+	var signer *openpgp.Entity
+
+	if inRelease, err := httpGetBytes(base + "/InRelease"); err == nil {
+		rel, signer, err = c.TrustStore.VerifyInlineRelease(keyring, inRelease)
 		if err != nil {
-			c.ErrLog.Printf("failed to create releases file %s: %s", releasesFile, err)
-			return err
+			return nil, err
+		}
+	} else {
+		release, err := httpGetBytes(base + "/Release")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Release for %s: %w", source.URI, err)
+		}
+		sig, err := httpGetBytes(base + "/Release.gpg")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Release.gpg for %s: %w", source.URI, err)
+		}
+		signer, err = c.TrustStore.VerifyRelease(keyring, release, sig)
+		if err != nil {
+			return nil, err
+		}
+		rel, err = parseReleaseStanza(bytes.NewReader(release))
+		if err != nil {
+			return nil, err
 		}
-		f.Close()
 	}
 
-	return nil
+	if err := c.TrustStore.CheckAndRecordRelease(releaseSourceKey(source), rel, signer); err != nil {
+		return nil, err
+	}
+	return rel, nil
+}
+
+func httpGetBytes(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+	return io.ReadAll(resp.Body)
 }
 
 func (c *AptCLient) Update() error {
@@ -292,17 +438,52 @@ func (c *AptCLient) Update() error {
 		c.ErrLog.Printf("failed to create index directory: %s", err)
 		return err
 	}
-	c.InfoLog.Printf("generating uris")
-	c.AptSourceRegistry.GenerateRepoURIs()
+
+	if c.MirrorOptions != nil {
+		if err := c.AptSourceRegistry.SelectFastestMirrors(context.Background(), *c.MirrorOptions); err != nil {
+			c.ErrLog.Printf("failed to select fastest mirrors: %s", err)
+			return err
+		}
+	}
+
 	reqs := []DownlaodRequest{}
-	for _, repoURI := range c.AptSourceRegistry.RepoURIs {
-		reqs = append(reqs, DownlaodRequest{
-			URI:         fmt.Sprintf("%s/binary-amd64/Packages.gz", repoURI),
-			Destination: fmt.Sprintf("%s/%s_Packages.gz", indexDir, URLtoFilename(repoURI)),
-		})
-	}
-	c.DownloadManager.Download(reqs, c.InfoLog, c.ErrLog)
-	err = ExtractIndexes(indexDir, c.InfoLog, c.ErrLog)
+
+	for _, source := range c.AptSourceRegistry.Sources {
+		rel, err := c.fetchReleaseAndVerify(source)
+		if err != nil {
+			c.ErrLog.Printf("failed to verify Release for %s: %s", source.URI, err)
+			return err
+		}
+		archs := source.Architectures
+		if len(archs) == 0 {
+			archs = []string{"amd64"}
+		}
+		for _, component := range source.Components {
+			repoURI := fmt.Sprintf("%s/%s/%s/%s", source.URI, "dists", source.Suite, component)
+			for _, arch := range archs {
+				relPath := fmt.Sprintf("%s/binary-%s/Packages.gz", component, arch)
+				checksum, ok := rel.SHA256[relPath]
+				if !ok {
+					c.ErrLog.Printf("Release for %s does not list a checksum for %s, skipping", source.URI, relPath)
+					continue
+				}
+				archRepoURI := fmt.Sprintf("%s/binary-%s", repoURI, arch)
+				reqs = append(reqs, DownlaodRequest{
+					URI:         fmt.Sprintf("%s/Packages.gz", archRepoURI),
+					Destination: fmt.Sprintf("%s/%s_Packages.gz", indexDir, URLtoFilename(archRepoURI)),
+					Expected:    checksum,
+					MaxTries:    3,
+				})
+			}
+		}
+	}
+
+	c.Progress.Start()
+	defer c.Progress.Shutdown()
+
+	c.DownloadManager.Download(reqs, c.Progress)
+
+	err = ExtractIndexes(indexDir, c.Progress)
 	if err != nil {
 		c.ErrLog.Printf("failed to extract indexes: %s", err)
 		return err
@@ -310,15 +491,135 @@ func (c *AptCLient) Update() error {
 	return nil
 }
 
+// LoadCatalog parses every extracted Packages index under AptfDir/index
+// into a single deb.PackageList, remembering which AptSource each package
+// came from. Search, Show, and Download call this automatically the first
+// time they need it; call it directly to refresh the catalog after Update.
+func (c *AptCLient) LoadCatalog() error {
+	indexDir := filepath.Join(c.AptfDir, "index")
+	catalog := deb.NewPackageList()
+	origins := map[deb.PackageKey]*AptSource{}
+
+	for _, source := range c.AptSourceRegistry.Sources {
+		archs := source.Architectures
+		if len(archs) == 0 {
+			archs = []string{"amd64"}
+		}
+		for _, component := range source.Components {
+			repoURI := fmt.Sprintf("%s/%s/%s/%s", source.URI, "dists", source.Suite, component)
+			for _, arch := range archs {
+				archRepoURI := fmt.Sprintf("%s/binary-%s", repoURI, arch)
+				path := filepath.Join(indexDir, URLtoFilename(archRepoURI)+"_Packages")
+
+				f, err := os.Open(path)
+				if err != nil {
+					if os.IsNotExist(err) {
+						continue
+					}
+					return err
+				}
+				pkgs, err := deb.ParsePackages(f)
+				f.Close()
+				if err != nil {
+					return fmt.Errorf("failed to parse %s: %w", path, err)
+				}
+
+				for _, p := range pkgs.All() {
+					catalog.Add(p)
+					origins[deb.PackageKey{Name: p.Name, Architecture: p.Architecture, Version: p.Version}] = source
+				}
+			}
+		}
+	}
+
+	c.Catalog = catalog
+	c.catalogOrigins = origins
+	return nil
+}
+
+// Search returns every package whose name or Provides entries contain
+// query, loading the catalog first if it hasn't been built yet.
+func (c *AptCLient) Search(query string) ([]*deb.Package, error) {
+	if c.Catalog == nil {
+		if err := c.LoadCatalog(); err != nil {
+			return nil, err
+		}
+	}
+	return c.Catalog.Search(query), nil
+}
+
+// Show returns every known version/architecture of the package named name,
+// loading the catalog first if it hasn't been built yet.
+func (c *AptCLient) Show(name string) ([]*deb.Package, error) {
+	if c.Catalog == nil {
+		if err := c.LoadCatalog(); err != nil {
+			return nil, err
+		}
+	}
+	pkgs := c.Catalog.ByName(name)
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no package named %s found", name)
+	}
+	return pkgs, nil
+}
+
+// Download resolves name via Show (using its first match) and fetches its
+// .deb file - the join of the owning AptSource's URI and the stanza's
+// Filename - into "<AptfDir>/pool/<basename>", verified against the
+// stanza's SHA256. It returns the path the file was saved to.
+func (c *AptCLient) Download(name string) (string, error) {
+	pkgs, err := c.Show(name)
+	if err != nil {
+		return "", err
+	}
+	p := pkgs[0]
+
+	if p.Filename == "" {
+		return "", fmt.Errorf("package %s has no Filename", name)
+	}
+	if p.SHA256 == "" {
+		return "", fmt.Errorf("package %s has no SHA256 checksum", name)
+	}
+	source, ok := c.catalogOrigins[deb.PackageKey{Name: p.Name, Architecture: p.Architecture, Version: p.Version}]
+	if !ok {
+		return "", fmt.Errorf("could not determine source for package %s", name)
+	}
+
+	poolDir := filepath.Join(c.AptfDir, "pool")
+	if err := makeDirectoryIfNotExists(poolDir); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(poolDir, filepath.Base(p.Filename))
+	url := fmt.Sprintf("%s/%s", source.URI, p.Filename)
+
+	bar := c.Progress.AddBar(0)
+	bar.SetName(p.Filename)
+	defer bar.Finish()
+
+	downloader := NewDownloader(c.InfoLog, c.ErrLog)
+	if err := downloader.DownloadWithChecksum(url, dest, ChecksumInfo{SHA256: p.SHA256}, 3, bar); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
 func NewAptCLient(aptfDir string, infoLog, errLog *log.Logger) *AptCLient {
 	err := makeDirectoryIfNotExists(aptfDir)
 	if err != nil {
 		return nil
 	}
+	trustStore, err := NewTrustStore(filepath.Join(aptfDir, "trust"))
+	if err != nil {
+		errLog.Printf("failed to initialize trust store: %s", err)
+		return nil
+	}
 	return &AptCLient{
 		AptfDir:           aptfDir,
 		AptSourceRegistry: &AptSourceRegistry{},
 		DownloadManager:   NewDownloadManager(10),
+		TrustStore:        trustStore,
+		Progress:          NewProgress(infoLog, errLog),
 		InfoLog:           infoLog,
 		ErrLog:            errLog,
 	}
@@ -329,8 +630,9 @@ type ExtractionMessage struct {
 	Err     error
 }
 
-// ExtractIndexes extracts all indexes in the given directory at once.
-func ExtractIndexes(dir string, infoLog, errLog *log.Logger) error {
+// ExtractIndexes extracts all indexes in the given directory at once,
+// reporting per-file progress through progress.
+func ExtractIndexes(dir string, progress Progress) error {
 	// Get the list of files
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -343,13 +645,13 @@ func ExtractIndexes(dir string, infoLog, errLog *log.Logger) error {
 		// Extract the index
 		out, err := recreateFile(filepath.Join(dir, strings.TrimSuffix(f.Name(), ".gz")))
 		if err != nil {
-			errLog.Printf("failed to extract %s", f.Name())
+			progress.Printf("failed to extract %s", f.Name())
 			return err
 		}
 		defer out.Close()
 		in, err := os.Open(filepath.Join(dir, f.Name()))
 		if err != nil {
-			errLog.Printf("failed to extract %s", f.Name())
+			progress.Printf("failed to extract %s", f.Name())
 
 			return err
 
@@ -357,16 +659,20 @@ func ExtractIndexes(dir string, infoLog, errLog *log.Logger) error {
 		defer in.Close()
 		gz, err := gzip.NewReader(in)
 		if err != nil {
-			errLog.Printf("failed to extract %s", f.Name())
+			progress.Printf("failed to extract %s", f.Name())
 			return err
 
 		}
-		_, err = io.Copy(out, gz)
+
+		bar := progress.AddBar(f.Size())
+		bar.SetName(f.Name())
+		_, err = io.Copy(out, io.TeeReader(gz, bar))
+		bar.Finish()
 		if err != nil {
-			errLog.Printf("failed to extract %s", f.Name())
+			progress.Printf("failed to extract %s", f.Name())
 			return err
 		}
-		infoLog.Printf("extracted %s", f.Name())
+		progress.Printf("extracted %s", f.Name())
 	}
 	return nil
 }
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/google/uuid"
@@ -34,6 +37,24 @@ func TestSourceFromString(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid source string with inline options",
+			args: args{
+				s: "deb [arch=amd64,armhf signed-by=/usr/share/keyrings/ubuntu.gpg] http://archive.ubuntu.com/ubuntu trusty main restricted",
+				uuidProvider: func() uuid.UUID {
+					return uuid.MustParse("00000000-0000-0000-0000-000000000000")
+				},
+			},
+			want: &AptSource{
+				ID:            uuid.MustParse("00000000-0000-0000-0000-000000000000"),
+				URI:           "http://archive.ubuntu.com/ubuntu",
+				Suite:         "trusty",
+				Components:    []string{"main", "restricted"},
+				Architectures: []string{"amd64", "armhf"},
+				SignedBy:      "/usr/share/keyrings/ubuntu.gpg",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -88,6 +109,67 @@ func TestAptSourceRegistry_AddSource(t *testing.T) {
 	}
 }
 
+func TestParseSourcesListDispatchesOnExtension(t *testing.T) {
+	dir := t.TempDir()
+
+	listPath := filepath.Join(dir, "ubuntu.list")
+	if err := os.WriteFile(listPath, []byte("deb http://archive.ubuntu.com/ubuntu trusty main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := ParseSourcesList(listPath)
+	if err != nil {
+		t.Fatalf("ParseSourcesList(%s) error = %v", listPath, err)
+	}
+	if len(registry.Sources) != 1 || registry.Sources[0].Suite != "trusty" {
+		t.Errorf("ParseSourcesList(%s) = %+v, want a single trusty source", listPath, registry.Sources)
+	}
+
+	sourcesPath := filepath.Join(dir, "debian.sources")
+	if err := os.WriteFile(sourcesPath, []byte("Types: deb\nURIs: http://deb.debian.org/debian\nSuites: bookworm\nComponents: main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err = ParseSourcesList(sourcesPath)
+	if err != nil {
+		t.Fatalf("ParseSourcesList(%s) error = %v", sourcesPath, err)
+	}
+	if len(registry.Sources) != 1 || registry.Sources[0].Suite != "bookworm" {
+		t.Errorf("ParseSourcesList(%s) = %+v, want a single bookworm source", sourcesPath, registry.Sources)
+	}
+}
+
+func TestParseSourcesListMergesSourcesListDir(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"ubuntu.list":    "deb http://archive.ubuntu.com/ubuntu trusty main\n",
+		"debian.sources": "Types: deb\nURIs: http://deb.debian.org/debian\nSuites: bookworm\nComponents: main\n",
+		"README":         "this is not a sources file and must be skipped\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry, err := ParseSourcesList(dir)
+	if err != nil {
+		t.Fatalf("ParseSourcesList(%s) error = %v", dir, err)
+	}
+
+	var suites []string
+	for _, s := range registry.Sources {
+		suites = append(suites, s.Suite)
+	}
+	sort.Strings(suites)
+	if want := []string{"bookworm", "trusty"}; !reflect.DeepEqual(suites, want) {
+		t.Errorf("merged Suites = %v, want %v (README should have been skipped)", suites, want)
+	}
+
+	if len(registry.RepoURIs) == 0 {
+		t.Error("merged registry has no RepoURIs; GenerateRepoURIs should run over every merged source")
+	}
+}
+
 func TestAptSourceRegistry_GenerateRepoURIs(t *testing.T) {
 	type fields struct {
 		Sources  []*AptSource
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MirrorCandidateProvider supplies the candidate mirror base URIs to probe
+// for source, e.g. by fetching a vendor's published mirror list. Callers can
+// supply their own for archives (Debian, Kali, ...) that aren't built in.
+type MirrorCandidateProvider func(ctx context.Context, source *AptSource) ([]string, error)
+
+// MirrorSelectOptions configures AptSourceRegistry.SelectFastestMirrors.
+type MirrorSelectOptions struct {
+	// Concurrency bounds how many candidates are probed at once.
+	Concurrency int
+	// Timeout bounds each individual probe request.
+	Timeout time.Duration
+	// CandidateProvider supplies the mirrors to probe for a source. Defaults
+	// to UbuntuMirrorCandidates.
+	CandidateProvider MirrorCandidateProvider
+	// CacheDir, if set, is where the chosen mirror and its measured latency
+	// are persisted so later calls can skip re-probing. Typically
+	// "<aptfDir>/mirrors".
+	CacheDir string
+	// TTL is how long a cached choice remains valid before it is re-probed.
+	// A zero or negative TTL means a cached choice never expires.
+	TTL time.Duration
+	// Refresh forces re-probing even if a cached choice has not expired,
+	// mirroring a --refresh-mirrors flag.
+	Refresh bool
+}
+
+// DefaultMirrorSelectOptions returns sane defaults: 8-way concurrency, a
+// 5 second per-probe timeout, Ubuntu's published mirror list, and a 24 hour
+// cache TTL, persisting choices under cacheDir.
+func DefaultMirrorSelectOptions(cacheDir string) MirrorSelectOptions {
+	return MirrorSelectOptions{
+		Concurrency:       8,
+		Timeout:           5 * time.Second,
+		CandidateProvider: UbuntuMirrorCandidates,
+		CacheDir:          cacheDir,
+		TTL:               24 * time.Hour,
+	}
+}
+
+// mirrorChoice is the on-disk record of a previously selected mirror.
+type mirrorChoice struct {
+	OriginalURI string        `json:"original_uri"`
+	SelectedURI string        `json:"selected_uri"`
+	Latency     time.Duration `json:"latency"`
+	CheckedAt   time.Time     `json:"checked_at"`
+}
+
+func mirrorCacheKey(source *AptSource) string {
+	sum := sha256.Sum256([]byte(source.URI + "|" + source.Suite))
+	return hex.EncodeToString(sum[:])
+}
+
+func (o MirrorSelectOptions) cachePath(source *AptSource) string {
+	if o.CacheDir == "" {
+		return ""
+	}
+	return filepath.Join(o.CacheDir, mirrorCacheKey(source)+".json")
+}
+
+func loadMirrorChoice(path string) (*mirrorChoice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var choice mirrorChoice
+	if err := json.Unmarshal(data, &choice); err != nil {
+		return nil, err
+	}
+	return &choice, nil
+}
+
+func saveMirrorChoice(path string, choice *mirrorChoice) error {
+	if err := makeDirectoryIfNotExists(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(choice)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// SelectFastestMirrors probes candidate mirrors for every source in the
+// registry concurrently and rewrites each AptSource.URI to the
+// lowest-latency candidate found, then regenerates RepoURIs so it reflects
+// the rewritten URIs rather than the ones ParseSourcesList/ParseDEB822Sources
+// originally computed. A cached choice is reused, without probing, until it
+// is older than opts.TTL (never, if opts.TTL <= 0) or opts.Refresh is set.
+func (a *AptSourceRegistry) SelectFastestMirrors(ctx context.Context, opts MirrorSelectOptions) error {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.CandidateProvider == nil {
+		opts.CandidateProvider = UbuntuMirrorCandidates
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+
+	for _, source := range a.Sources {
+		cachePath := opts.cachePath(source)
+		if cachePath != "" && !opts.Refresh {
+			if choice, err := loadMirrorChoice(cachePath); err == nil {
+				if opts.TTL <= 0 || time.Since(choice.CheckedAt) < opts.TTL {
+					source.URI = choice.SelectedURI
+					continue
+				}
+			}
+		}
+
+		candidates, err := opts.CandidateProvider(ctx, source)
+		if err != nil {
+			return fmt.Errorf("failed to list mirror candidates for %s: %w", source.URI, err)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		best, latency, err := probeCandidates(ctx, client, candidates, source.Suite, opts.Concurrency)
+		if err != nil {
+			return fmt.Errorf("failed to probe mirrors for %s: %w", source.URI, err)
+		}
+
+		choice := &mirrorChoice{
+			OriginalURI: source.URI,
+			SelectedURI: best,
+			Latency:     latency,
+			CheckedAt:   time.Now(),
+		}
+		if cachePath != "" {
+			if err := saveMirrorChoice(cachePath, choice); err != nil {
+				return err
+			}
+		}
+		source.URI = best
+	}
+	a.GenerateRepoURIs()
+	return nil
+}
+
+type mirrorProbeResult struct {
+	URI     string
+	Latency time.Duration
+	Err     error
+}
+
+// probeCandidates HEAD-requests dists/<suite>/Release on every candidate
+// through a bounded worker pool and returns the one with the lowest latency.
+func probeCandidates(ctx context.Context, client *http.Client, candidates []string, suite string, concurrency int) (string, time.Duration, error) {
+	jobs := make(chan string)
+	results := make(chan mirrorProbeResult, len(candidates))
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range jobs {
+				latency, err := probeMirror(ctx, client, candidate, suite)
+				results <- mirrorProbeResult{URI: candidate, Latency: latency, Err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, c := range candidates {
+			jobs <- c
+		}
+		close(jobs)
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var best string
+	var bestLatency time.Duration
+	found := false
+	for r := range results {
+		if r.Err != nil {
+			continue
+		}
+		if !found || r.Latency < bestLatency {
+			best = r.URI
+			bestLatency = r.Latency
+			found = true
+		}
+	}
+	if !found {
+		return "", 0, fmt.Errorf("no mirror candidates responded")
+	}
+	return best, bestLatency, nil
+}
+
+// probeMirror HEAD-requests a known file under candidate and returns the
+// measured round trip time.
+func probeMirror(ctx context.Context, client *http.Client, candidate, suite string) (time.Duration, error) {
+	url := fmt.Sprintf("%s/dists/%s/Release", strings.TrimSuffix(candidate, "/"), suite)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return 0, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+	return time.Since(start), nil
+}
+
+// UbuntuMirrorCandidates fetches Ubuntu's published mirror list and returns
+// each mirror's base archive URI. It is the default MirrorCandidateProvider.
+func UbuntuMirrorCandidates(ctx context.Context, source *AptSource) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://mirrors.ubuntu.com/mirrors.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching mirror list", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var mirrors []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		mirrors = append(mirrors, strings.TrimSuffix(line, "/"))
+	}
+	return mirrors, nil
+}
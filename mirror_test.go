@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMirrorCacheKeyIsStablePerSourceAndSuite(t *testing.T) {
+	a := &AptSource{URI: "http://archive.ubuntu.com/ubuntu", Suite: "focal"}
+	b := &AptSource{URI: "http://archive.ubuntu.com/ubuntu", Suite: "jammy"}
+
+	if mirrorCacheKey(a) == mirrorCacheKey(b) {
+		t.Error("mirrorCacheKey() matched for different suites")
+	}
+	if mirrorCacheKey(a) != mirrorCacheKey(a) {
+		t.Error("mirrorCacheKey() is not stable for the same source")
+	}
+}
+
+func TestProbeCandidatesPicksLowestLatency(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	client := &http.Client{Timeout: time.Second}
+	best, _, err := probeCandidates(context.Background(), client, []string{broken.URL, slow.URL, fast.URL}, "focal", 3)
+	if err != nil {
+		t.Fatalf("probeCandidates() error = %v", err)
+	}
+	if best != fast.URL {
+		t.Errorf("probeCandidates() = %q, want the fastest candidate %q", best, fast.URL)
+	}
+}
+
+func TestProbeCandidatesErrorsWhenAllFail(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	client := &http.Client{Timeout: time.Second}
+	if _, _, err := probeCandidates(context.Background(), client, []string{broken.URL}, "focal", 1); err == nil {
+		t.Error("probeCandidates() with no healthy candidates unexpectedly succeeded")
+	}
+}
+
+func TestSelectFastestMirrorsRewritesSourceURI(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+
+	source := &AptSource{URI: "http://original.example.com", Suite: "focal"}
+	registry := &AptSourceRegistry{Sources: []*AptSource{source}}
+
+	opts := MirrorSelectOptions{
+		Concurrency: 2,
+		Timeout:     time.Second,
+		CandidateProvider: func(ctx context.Context, s *AptSource) ([]string, error) {
+			return []string{slow.URL, fast.URL}, nil
+		},
+	}
+
+	if err := registry.SelectFastestMirrors(context.Background(), opts); err != nil {
+		t.Fatalf("SelectFastestMirrors() error = %v", err)
+	}
+	if source.URI != fast.URL {
+		t.Errorf("source.URI = %q, want %q", source.URI, fast.URL)
+	}
+}
+
+func TestSelectFastestMirrorsRegeneratesRepoURIs(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+
+	source := &AptSource{URI: "http://original.example.com", Suite: "focal", Components: []string{"main"}}
+	registry := &AptSourceRegistry{Sources: []*AptSource{source}}
+	registry.GenerateRepoURIs()
+
+	opts := MirrorSelectOptions{
+		Concurrency: 1,
+		Timeout:     time.Second,
+		CandidateProvider: func(ctx context.Context, s *AptSource) ([]string, error) {
+			return []string{fast.URL}, nil
+		},
+	}
+
+	if err := registry.SelectFastestMirrors(context.Background(), opts); err != nil {
+		t.Fatalf("SelectFastestMirrors() error = %v", err)
+	}
+
+	want := fast.URL + "/dists/focal/main"
+	if len(registry.RepoURIs) != 1 || registry.RepoURIs[0] != want {
+		t.Errorf("RepoURIs = %v, want [%q] (stale pre-mirror-selection URI)", registry.RepoURIs, want)
+	}
+}
+
+func TestSelectFastestMirrorsUsesCacheWithinTTL(t *testing.T) {
+	calls := 0
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer candidate.Close()
+
+	cacheDir := t.TempDir()
+	opts := MirrorSelectOptions{
+		Concurrency: 1,
+		Timeout:     time.Second,
+		CacheDir:    cacheDir,
+		TTL:         time.Hour,
+		CandidateProvider: func(ctx context.Context, s *AptSource) ([]string, error) {
+			return []string{candidate.URL}, nil
+		},
+	}
+
+	// Model two separate process runs, each parsing the same configured
+	// source fresh: the second run's source starts with the same
+	// (pre-rewrite) URI as the first, so it hashes to the same cache entry.
+	first := &AptSource{URI: "http://original.example.com", Suite: "focal"}
+	if err := (&AptSourceRegistry{Sources: []*AptSource{first}}).SelectFastestMirrors(context.Background(), opts); err != nil {
+		t.Fatalf("SelectFastestMirrors() first run error = %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d after first run, want 1", calls)
+	}
+	if first.URI != candidate.URL {
+		t.Fatalf("first.URI = %q, want %q", first.URI, candidate.URL)
+	}
+
+	second := &AptSource{URI: "http://original.example.com", Suite: "focal"}
+	if err := (&AptSourceRegistry{Sources: []*AptSource{second}}).SelectFastestMirrors(context.Background(), opts); err != nil {
+		t.Fatalf("SelectFastestMirrors() second run error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d after second run, want 1 (cached choice should be reused)", calls)
+	}
+	if second.URI != candidate.URL {
+		t.Errorf("second.URI = %q, want cached choice %q", second.URI, candidate.URL)
+	}
+}
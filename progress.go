@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// Progress reports progress for long-running operations (downloads, index
+// extraction), in the style of aptly's Progress interface. Implementations
+// must be safe for concurrent use, since DownloadManager drives many bars
+// at once from its worker pool.
+type Progress interface {
+	// Start prepares the renderer. Shutdown must be called to stop it.
+	Start()
+	// AddBar registers a new bar for an operation of the given total size
+	// (in bytes; 0 if unknown) and returns a ProgressBar to report against.
+	AddBar(total int64) ProgressBar
+	// Printf writes a formatted status line.
+	Printf(format string, args ...interface{})
+	// Shutdown stops the renderer and flushes any remaining output.
+	Shutdown()
+}
+
+// ProgressBar tracks a single operation's progress. It implements io.Writer
+// so it can be used as the destination of an io.TeeReader, making
+// bytes-in-flight visible as they're read rather than only once a transfer
+// completes.
+type ProgressBar interface {
+	io.Writer
+	// SetName labels the bar, e.g. with the file being downloaded.
+	SetName(name string)
+	// Finish marks the bar as complete. The bar keeps its place in the
+	// renderer; it is not removed.
+	Finish()
+}
+
+// NewProgress returns a multi-bar Progress backed by schollz/progressbar
+// when stdout is a terminal, or a plain logger-backed Progress (see
+// NewLogProgress) otherwise.
+func NewProgress(infoLog, errLog *log.Logger) Progress {
+	if isTerminal(os.Stdout) {
+		return NewBarProgress()
+	}
+	return NewLogProgress(infoLog, errLog)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressWriter adapts a Progress to an io.Writer, so a *log.Logger can be
+// built on top of it for code (like httpDownloader) that predates Progress
+// and still expects to log through a *log.Logger.
+type progressWriter struct {
+	progress Progress
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	w.progress.Printf("%s", strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+// progressLogger returns a *log.Logger that reports through progress.
+func progressLogger(progress Progress) *log.Logger {
+	return log.New(progressWriter{progress: progress}, "", 0)
+}
+
+// logProgress is a no-op Progress for non-TTY output that reports through
+// infoLog. Every message, failures included, goes to infoLog; errLog is
+// kept but never written to.
+type logProgress struct {
+	infoLog *log.Logger
+	errLog  *log.Logger
+}
+
+// NewLogProgress returns a Progress that logs every message through
+// infoLog instead of rendering bars; errLog is not written to.
+func NewLogProgress(infoLog, errLog *log.Logger) Progress {
+	return &logProgress{infoLog: infoLog, errLog: errLog}
+}
+
+func (p *logProgress) Start()    {}
+func (p *logProgress) Shutdown() {}
+
+func (p *logProgress) Printf(format string, args ...interface{}) {
+	p.infoLog.Printf(format, args...)
+}
+
+func (p *logProgress) AddBar(total int64) ProgressBar {
+	return &logProgressBar{infoLog: p.infoLog}
+}
+
+type logProgressBar struct {
+	infoLog *log.Logger
+	name    string
+	written int64
+}
+
+func (b *logProgressBar) SetName(name string) { b.name = name }
+
+func (b *logProgressBar) Write(p []byte) (int, error) {
+	b.written += int64(len(p))
+	return len(p), nil
+}
+
+func (b *logProgressBar) Finish() {
+	b.infoLog.Printf("downloaded %s (%d bytes)", b.name, b.written)
+}
+
+// barProgress is a Progress backed by schollz/progressbar, rendering one
+// bar per in-flight operation on its own terminal row, for use when stdout
+// is a TTY. schollz/progressbar assumes sole ownership of the current
+// line (it renders with a bare "\r"), so independent bars driven by
+// DownloadManager's concurrent workers would otherwise stomp on each
+// other; every bar here is instead given a barRowWriter bound to the row it
+// was assigned at creation, and all of them funnel through mu so only one
+// goroutine moves the cursor and writes at a time.
+type barProgress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	bars    []*progressbar.ProgressBar
+	started time.Time
+}
+
+// NewBarProgress returns a TTY multi-bar Progress.
+func NewBarProgress() Progress {
+	return &barProgress{out: os.Stdout}
+}
+
+func (p *barProgress) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started = time.Now()
+}
+
+// AddBar reserves the next terminal row (printing a blank line for it) and
+// returns a bar whose writes are confined to that row.
+func (p *barProgress) AddBar(total int64) ProgressBar {
+	p.mu.Lock()
+	row := len(p.bars)
+	fmt.Fprintln(p.out)
+	p.bars = append(p.bars, nil) // reserve the row before releasing mu
+	p.mu.Unlock()
+
+	bar := progressbar.NewOptions64(total,
+		progressbar.OptionSetWriter(&barRowWriter{progress: p, row: row}),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionShowCount(),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionThrottle(100*time.Millisecond),
+	)
+
+	p.mu.Lock()
+	p.bars[row] = bar
+	p.mu.Unlock()
+
+	return &barProgressBar{bar: bar}
+}
+
+func (p *barProgress) Printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.out, format+"\n", args...)
+}
+
+func (p *barProgress) Shutdown() {
+	p.mu.Lock()
+	elapsed := time.Since(p.started)
+	bars := append([]*progressbar.ProgressBar(nil), p.bars...)
+	p.mu.Unlock()
+
+	for _, bar := range bars {
+		if bar != nil {
+			bar.Finish()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(bars) > 0 {
+		fmt.Fprintf(p.out, "done in %s\n", elapsed.Round(time.Millisecond))
+	}
+}
+
+// barRowWriter is the io.Writer a single bar renders through. Every write
+// moves the cursor up from the current bottom row to this bar's row,
+// overwrites it, and moves back down, all while holding progress.mu so
+// concurrent bars can't interleave their cursor movements.
+type barRowWriter struct {
+	progress *barProgress
+	row      int
+}
+
+func (w *barRowWriter) Write(data []byte) (int, error) {
+	w.progress.mu.Lock()
+	defer w.progress.mu.Unlock()
+
+	up := len(w.progress.bars) - w.row
+	if up > 0 {
+		fmt.Fprintf(w.progress.out, "\033[%dA", up)
+	}
+	fmt.Fprint(w.progress.out, "\r")
+	n, err := w.progress.out.Write(data)
+	if up > 0 {
+		fmt.Fprintf(w.progress.out, "\033[%dB", up)
+	}
+	return n, err
+}
+
+type barProgressBar struct {
+	bar *progressbar.ProgressBar
+}
+
+func (b *barProgressBar) SetName(name string) {
+	b.bar.Describe(name)
+}
+
+func (b *barProgressBar) Write(p []byte) (int, error) {
+	return b.bar.Write(p)
+}
+
+func (b *barProgressBar) Finish() {
+	b.bar.Finish()
+}
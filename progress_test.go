@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+func TestLogProgressBarReportsBytesWritten(t *testing.T) {
+	var buf bytes.Buffer
+	infoLog := log.New(&buf, "", 0)
+	errLog := log.New(&buf, "", 0)
+
+	progress := NewLogProgress(infoLog, errLog)
+	bar := progress.AddBar(11)
+	bar.SetName("example.txt")
+
+	n, err := bar.Write([]byte("hello world"))
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if n != 11 {
+		t.Errorf("Write() = %d, want 11", n)
+	}
+
+	bar.Finish()
+
+	if got := buf.String(); !bytes.Contains([]byte(got), []byte("example.txt (11 bytes)")) {
+		t.Errorf("Finish() did not log bytes written, got %q", got)
+	}
+}
+
+// TestBarRowWriterSerializesConcurrentBars demonstrates the bug two
+// concurrently-driven bars used to hit: each one's renders used to go
+// straight to os.Stdout with no coordination, so two bars updating at once
+// could interleave mid-write and corrupt both lines. Every write here is a
+// run of 10 identical bytes ('X' for row 0, 'Y' for row 1); if writes from
+// the two rows ever interleaved, we'd see a run whose length isn't a
+// multiple of 10.
+func TestBarRowWriterSerializesConcurrentBars(t *testing.T) {
+	var buf bytes.Buffer
+	p := &barProgress{out: &buf, bars: []*progressbar.ProgressBar{nil, nil}}
+
+	row0 := &barRowWriter{progress: p, row: 0}
+	row1 := &barRowWriter{progress: p, row: 1}
+
+	// X and Y (rather than the more obvious A/B) so the payload can't be
+	// confused with the 'A'/'B' cursor-up/cursor-down escape codes each
+	// Write also emits.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			row0.Write([]byte("XXXXXXXXXX"))
+		}()
+		go func() {
+			defer wg.Done()
+			row1.Write([]byte("YYYYYYYYYY"))
+		}()
+	}
+	wg.Wait()
+
+	for _, run := range regexp.MustCompile(`X+|Y+`).FindAllString(buf.String(), -1) {
+		if len(run)%10 != 0 {
+			t.Fatalf("interleaved write detected: run of length %d is not a multiple of 10: %q", len(run), run)
+		}
+	}
+}
+
+// TestBarProgressRendersBarsOnDistinctRows is a manual-inspection aid: run
+// with `go test -run BarProgressRendersBarsOnDistinctRows -v` in a real
+// terminal to watch two bars update side by side on their own rows rather
+// than overwriting one another.
+func TestBarProgressRendersBarsOnDistinctRows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("manual TTY demonstration, skipped under -short")
+	}
+
+	p := NewBarProgress().(*barProgress)
+	p.Start()
+	bar0 := p.AddBar(100)
+	bar0.SetName("file-a")
+	bar1 := p.AddBar(100)
+	bar1.SetName("file-b")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			bar0.Write(make([]byte, 10))
+		}
+		bar0.Finish()
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			bar1.Write(make([]byte, 10))
+		}
+		bar1.Finish()
+	}()
+	wg.Wait()
+	p.Shutdown()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChecksumInfo is one entry from a Release file's MD5Sum/SHA1/SHA256
+// fields: the size and repo-relative path of an index file it covers, plus
+// whichever hashes the Release stanza listed for it.
+type ChecksumInfo struct {
+	MD5    string
+	SHA1   string
+	SHA256 string
+	Size   int64
+	Path   string
+}
+
+// ReleaseInfo is the subset of an apt Release stanza that Update needs in
+// order to validate and trust a repository snapshot.
+type ReleaseInfo struct {
+	Suite         string
+	Codename      string
+	Date          time.Time
+	ValidUntil    time.Time
+	Architectures []string
+	Components    []string
+	SHA256        map[string]ChecksumInfo // keyed by the repo-relative Path
+}
+
+// readDeb822Stanza reads a single RFC822-style (deb822) stanza from r into
+// a map of field name to value, joining continuation lines (those starting
+// with whitespace, as used by the multi-line SHA256/Description fields)
+// onto the previous field's value.
+func readDeb822Stanza(r io.Reader) (map[string]string, error) {
+	fields := map[string]string{}
+	var lastKey string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if lastKey == "" {
+				continue
+			}
+			fields[lastKey] += "\n" + strings.TrimPrefix(line, " ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		fields[key] = strings.TrimSpace(value)
+		lastKey = key
+	}
+	return fields, scanner.Err()
+}
+
+// parseReleaseStanza parses an apt Release file into a ReleaseInfo,
+// including the per-file checksums listed under the SHA256 field.
+func parseReleaseStanza(r io.Reader) (*ReleaseInfo, error) {
+	fields, err := readDeb822Stanza(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rel := &ReleaseInfo{
+		Suite:    fields["Suite"],
+		Codename: fields["Codename"],
+		SHA256:   map[string]ChecksumInfo{},
+	}
+	if fields["Architectures"] != "" {
+		rel.Architectures = strings.Fields(fields["Architectures"])
+	}
+	if fields["Components"] != "" {
+		rel.Components = strings.Fields(fields["Components"])
+	}
+	if fields["Date"] != "" {
+		rel.Date, err = time.Parse(time.RFC1123, fields["Date"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Release Date %q: %w", fields["Date"], err)
+		}
+	}
+	if fields["Valid-Until"] != "" {
+		rel.ValidUntil, err = time.Parse(time.RFC1123, fields["Valid-Until"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Release Valid-Until %q: %w", fields["Valid-Until"], err)
+		}
+	}
+
+	mergeChecksumField(rel.SHA256, fields["MD5Sum"], func(c *ChecksumInfo, hash string) { c.MD5 = hash })
+	mergeChecksumField(rel.SHA256, fields["SHA1"], func(c *ChecksumInfo, hash string) { c.SHA1 = hash })
+	mergeChecksumField(rel.SHA256, fields["SHA256"], func(c *ChecksumInfo, hash string) { c.SHA256 = hash })
+
+	return rel, nil
+}
+
+// mergeChecksumField parses one of the Release stanza's hash fields
+// ("<hash> <size> <path>" per line) and merges each hash into the
+// ChecksumInfo for that path, creating it if this is the first field seen
+// for that path.
+func mergeChecksumField(into map[string]ChecksumInfo, field string, set func(c *ChecksumInfo, hash string)) {
+	for _, line := range strings.Split(field, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			continue
+		}
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		path := parts[2]
+		c := into[path]
+		c.Path = path
+		c.Size = size
+		set(&c, parts[0])
+		into[path] = c
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleRelease = `Suite: focal
+Codename: focal
+Date: Mon, 01 Jan 2024 00:00:00 UTC
+Valid-Until: Wed, 10 Jan 2024 00:00:00 UTC
+Architectures: amd64 arm64
+Components: main universe
+MD5Sum:
+ aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa 1000 main/binary-amd64/Packages.gz
+SHA256:
+ 1111111111111111111111111111111111111111111111111111111111111111 1000 main/binary-amd64/Packages.gz
+ 2222222222222222222222222222222222222222222222222222222222222222 2000 universe/binary-amd64/Packages.gz
+`
+
+func TestParseReleaseStanza(t *testing.T) {
+	rel, err := parseReleaseStanza(strings.NewReader(sampleRelease))
+	if err != nil {
+		t.Fatalf("parseReleaseStanza() error = %v", err)
+	}
+
+	if rel.Suite != "focal" {
+		t.Errorf("Suite = %q, want focal", rel.Suite)
+	}
+	wantDate := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if !rel.Date.Equal(wantDate) {
+		t.Errorf("Date = %v, want %v", rel.Date, wantDate)
+	}
+	wantValidUntil := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+	if !rel.ValidUntil.Equal(wantValidUntil) {
+		t.Errorf("ValidUntil = %v, want %v", rel.ValidUntil, wantValidUntil)
+	}
+	if got := strings.Join(rel.Architectures, ","); got != "amd64,arm64" {
+		t.Errorf("Architectures = %q", got)
+	}
+	if got := strings.Join(rel.Components, ","); got != "main,universe" {
+		t.Errorf("Components = %q", got)
+	}
+
+	checksum, ok := rel.SHA256["main/binary-amd64/Packages.gz"]
+	if !ok {
+		t.Fatal("SHA256 missing entry for main/binary-amd64/Packages.gz")
+	}
+	if checksum.MD5 != "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" {
+		t.Errorf("MD5 = %q", checksum.MD5)
+	}
+	if checksum.SHA256 != "1111111111111111111111111111111111111111111111111111111111111111" {
+		t.Errorf("SHA256 = %q", checksum.SHA256)
+	}
+	if checksum.Size != 1000 {
+		t.Errorf("Size = %d, want 1000", checksum.Size)
+	}
+}
+
+func TestMergeChecksumField(t *testing.T) {
+	into := map[string]ChecksumInfo{}
+	field := " 1111111111111111111111111111111111111111111111111111111111111111 1000 main/binary-amd64/Packages.gz\n" +
+		" not-three-fields\n" +
+		" 2222222222222222222222222222222222222222222222222222222222222222 2000 universe/binary-amd64/Packages.gz"
+
+	mergeChecksumField(into, field, func(c *ChecksumInfo, hash string) { c.SHA256 = hash })
+
+	if len(into) != 2 {
+		t.Fatalf("len(into) = %d, want 2", len(into))
+	}
+	c, ok := into["main/binary-amd64/Packages.gz"]
+	if !ok {
+		t.Fatal("missing entry for main/binary-amd64/Packages.gz")
+	}
+	if c.Size != 1000 || c.SHA256 != "1111111111111111111111111111111111111111111111111111111111111111" {
+		t.Errorf("got %+v", c)
+	}
+}
+
+func TestMergeChecksumFieldMergesAcrossFields(t *testing.T) {
+	into := map[string]ChecksumInfo{}
+	mergeChecksumField(into, " aaaa 1000 main/binary-amd64/Packages.gz", func(c *ChecksumInfo, hash string) { c.MD5 = hash })
+	mergeChecksumField(into, " bbbb 1000 main/binary-amd64/Packages.gz", func(c *ChecksumInfo, hash string) { c.SHA256 = hash })
+
+	c := into["main/binary-amd64/Packages.gz"]
+	if c.MD5 != "aaaa" || c.SHA256 != "bbbb" {
+		t.Errorf("got %+v, want both hashes merged into the same ChecksumInfo", c)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// deb822StanzaSep separates stanzas in a deb822 document: a line containing
+// only (optional) whitespace.
+var deb822StanzaSep = regexp.MustCompile(`\n[ \t]*\n`)
+
+// ParseDEB822Sources parses the modern deb822 ".sources" format (stanzas
+// with Types/URIs/Suites/Components/Architectures/Signed-By fields) used
+// under /etc/apt/sources.list.d/*.sources, returning one AptSource per
+// (URI, Suite) pair found across all stanzas in the file.
+func ParseDEB822Sources(path string) (*AptSourceRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &AptSourceRegistry{}
+	for _, block := range deb822StanzaSep.Split(strings.TrimSpace(string(data)), -1) {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		fields, err := readDeb822Stanza(strings.NewReader(block))
+		if err != nil {
+			return nil, err
+		}
+		sources, err := deb822StanzaToSources(fields)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		r.AddSources(sources)
+	}
+
+	r.GenerateRepoURIs()
+	return r, nil
+}
+
+// deb822StanzaToSources expands a single deb822 stanza into one AptSource
+// per (URI, Suite) pair, as apt does.
+func deb822StanzaToSources(fields map[string]string) ([]*AptSource, error) {
+	types := strings.Fields(fields["Types"])
+	if len(types) == 0 {
+		return nil, fmt.Errorf("deb822 stanza is missing Types")
+	}
+	isBinary := false
+	for _, t := range types {
+		if t == "deb" {
+			isBinary = true
+		}
+	}
+	if !isBinary {
+		return nil, fmt.Errorf("only binary (deb) repositories are supported")
+	}
+
+	uris := strings.Fields(fields["URIs"])
+	suites := strings.Fields(fields["Suites"])
+	if len(uris) == 0 || len(suites) == 0 {
+		return nil, fmt.Errorf("deb822 stanza requires at least one URI and Suite")
+	}
+
+	components := strings.Fields(fields["Components"])
+	architectures := strings.Fields(fields["Architectures"])
+	signedBy := strings.TrimSpace(fields["Signed-By"])
+
+	var sources []*AptSource
+	for _, uri := range uris {
+		for _, suite := range suites {
+			sources = append(sources, &AptSource{
+				ID:            uuid.New(),
+				URI:           strings.TrimSuffix(uri, "/"),
+				Suite:         suite,
+				Components:    components,
+				Architectures: architectures,
+				SignedBy:      signedBy,
+			})
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDEB822Sources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ubuntu.sources")
+	contents := `Types: deb
+URIs: http://archive.ubuntu.com/ubuntu/
+Suites: trusty
+Components: main restricted
+Architectures: amd64 arm64
+Signed-By: /usr/share/keyrings/ubuntu-archive-keyring.gpg
+`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := ParseDEB822Sources(path)
+	if err != nil {
+		t.Fatalf("ParseDEB822Sources() error = %v", err)
+	}
+	if len(registry.Sources) != 1 {
+		t.Fatalf("got %d sources, want 1", len(registry.Sources))
+	}
+
+	got := registry.Sources[0]
+	if got.URI != "http://archive.ubuntu.com/ubuntu" {
+		t.Errorf("URI = %q, want trimmed trailing slash", got.URI)
+	}
+	if got.Suite != "trusty" {
+		t.Errorf("Suite = %q, want %q", got.Suite, "trusty")
+	}
+	if len(got.Components) != 2 || got.Components[0] != "main" || got.Components[1] != "restricted" {
+		t.Errorf("Components = %v, want [main restricted]", got.Components)
+	}
+	if len(got.Architectures) != 2 || got.Architectures[0] != "amd64" || got.Architectures[1] != "arm64" {
+		t.Errorf("Architectures = %v, want [amd64 arm64]", got.Architectures)
+	}
+	if got.SignedBy != "/usr/share/keyrings/ubuntu-archive-keyring.gpg" {
+		t.Errorf("SignedBy = %q", got.SignedBy)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+)
+
+// TrustStore manages the PGP keyring a source's Release is verified against
+// and the record of previously accepted Release fingerprints, mirroring the
+// keyring-based trust model the debroot tool uses.
+type TrustStore struct {
+	KeysDir    string
+	HashesFile string
+}
+
+// NewTrustStore creates the trust directory layout under dir (normally
+// "<aptfDir>/trust") if it does not already exist and returns a TrustStore
+// bound to it.
+func NewTrustStore(dir string) (*TrustStore, error) {
+	if err := makeDirectoryIfNotExists(dir); err != nil {
+		return nil, err
+	}
+
+	keysDir := filepath.Join(dir, "keys")
+	if err := makeDirectoryIfNotExists(keysDir); err != nil {
+		return nil, err
+	}
+
+	hashesDir := filepath.Join(dir, "hashes")
+	if err := makeDirectoryIfNotExists(hashesDir); err != nil {
+		return nil, err
+	}
+
+	hashesFile := filepath.Join(hashesDir, "releases")
+	if _, err := os.Stat(hashesFile); os.IsNotExist(err) {
+		f, err := os.Create(hashesFile)
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+	}
+
+	return &TrustStore{KeysDir: keysDir, HashesFile: hashesFile}, nil
+}
+
+// Keyring loads every key file in KeysDir into a single openpgp.EntityList.
+func (t *TrustStore) Keyring() (openpgp.EntityList, error) {
+	files, err := os.ReadDir(t.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyring openpgp.EntityList
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		entities, err := readKeyringFile(filepath.Join(t.KeysDir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keyring = append(keyring, entities...)
+	}
+	return keyring, nil
+}
+
+// KeyringFor returns the keyring source's Release must be verified against.
+// If source.SignedBy is set, only the key(s) in that file (resolved against
+// KeysDir if it isn't already absolute) are trusted for this source - this
+// is what keeps one source's key from being able to sign another's Release.
+// If source.SignedBy is empty (a plain one-line source with no signed-by=
+// option), every key in KeysDir is trusted, preserving the original
+// shared-pool behavior for sources that don't opt into scoping.
+func (t *TrustStore) KeyringFor(source *AptSource) (openpgp.EntityList, error) {
+	if source.SignedBy == "" {
+		return t.Keyring()
+	}
+
+	path := source.SignedBy
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(t.KeysDir, path)
+	}
+	keyring, err := readKeyringFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signed-by keyring %s: %w", source.SignedBy, err)
+	}
+	return keyring, nil
+}
+
+// readKeyringFile reads a single key file, trying the armored format before
+// falling back to raw binary.
+func readKeyringFile(path string) (openpgp.EntityList, error) {
+	kf, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	entities, armorErr := openpgp.ReadArmoredKeyRing(kf)
+	kf.Close()
+	if armorErr == nil {
+		return entities, nil
+	}
+
+	kf, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer kf.Close()
+	entities, err = openpgp.ReadKeyRing(kf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", filepath.Base(path), err)
+	}
+	return entities, nil
+}
+
+// VerifyRelease checks the detached signature sig over the Release contents
+// against keyring, returning the signer.
+func (t *TrustStore) VerifyRelease(keyring openpgp.EntityList, release, sig []byte) (*openpgp.Entity, error) {
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("no trusted keys found in %s", t.KeysDir)
+	}
+
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(release), bytes.NewReader(sig))
+	if err != nil {
+		signer, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(release), bytes.NewReader(sig))
+		if err != nil {
+			return nil, fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+	return signer, nil
+}
+
+// VerifyInlineRelease verifies a clearsigned InRelease document against
+// keyring and returns the parsed Release stanza along with the signer.
+func (t *TrustStore) VerifyInlineRelease(keyring openpgp.EntityList, data []byte) (*ReleaseInfo, *openpgp.Entity, error) {
+	block, _ := clearsign.Decode(data)
+	if block == nil {
+		return nil, nil, fmt.Errorf("InRelease is not a valid clearsigned message")
+	}
+	if len(keyring) == 0 {
+		return nil, nil, fmt.Errorf("no trusted keys found in %s", t.KeysDir)
+	}
+
+	signer, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("InRelease signature verification failed: %w", err)
+	}
+
+	rel, err := parseReleaseStanza(bytes.NewReader(block.Plaintext))
+	if err != nil {
+		return nil, nil, err
+	}
+	return rel, signer, nil
+}
+
+// trustedRelease is a single line recorded in HashesFile: the source the
+// Release belongs to, the signer's fingerprint, and the Release's own
+// Date/Valid-Until so that a later run can detect a downgrade (freeze)
+// attack.
+type trustedRelease struct {
+	SourceKey   string
+	Fingerprint string
+	Date        time.Time
+	ValidUntil  time.Time
+}
+
+func (t *TrustStore) loadTrustedReleases() (map[string]trustedRelease, error) {
+	f, err := os.Open(t.HashesFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := map[string]trustedRelease{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 && len(fields) != 4 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			continue
+		}
+		var validUntil time.Time
+		if len(fields) == 4 && fields[3] != "" {
+			validUntil, err = time.Parse(time.RFC3339, fields[3])
+			if err != nil {
+				continue
+			}
+		}
+		records[fields[0]] = trustedRelease{SourceKey: fields[0], Fingerprint: fields[1], Date: date, ValidUntil: validUntil}
+	}
+	return records, scanner.Err()
+}
+
+func (t *TrustStore) saveTrustedReleases(records map[string]trustedRelease) error {
+	f, err := os.Create(t.HashesFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, r := range records {
+		validUntil := ""
+		if !r.ValidUntil.IsZero() {
+			validUntil = r.ValidUntil.Format(time.RFC3339)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.SourceKey, r.Fingerprint, r.Date.Format(time.RFC3339), validUntil); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// CheckAndRecordRelease guards against a downgrade (freeze) attack: if
+// sourceKey already has a recorded Release whose Date is newer than
+// rel.Date, or whose Valid-Until is newer than rel.ValidUntil, the new
+// Release is rejected. It also rejects rel outright if its own Valid-Until
+// has already passed. Otherwise the new fingerprint, Date, and Valid-Until
+// are persisted so the next Update can make the same comparison.
+func (t *TrustStore) CheckAndRecordRelease(sourceKey string, rel *ReleaseInfo, signer *openpgp.Entity) error {
+	if !rel.ValidUntil.IsZero() && time.Now().After(rel.ValidUntil) {
+		return fmt.Errorf("refusing Release for %s: it expired at %s (Valid-Until has passed)", sourceKey, rel.ValidUntil)
+	}
+
+	fingerprint := fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint)
+
+	records, err := t.loadTrustedReleases()
+	if err != nil {
+		return err
+	}
+
+	if prev, ok := records[sourceKey]; ok {
+		if rel.Date.Before(prev.Date) {
+			return fmt.Errorf("refusing Release for %s: Date %s is older than previously trusted %s (possible downgrade attack)", sourceKey, rel.Date, prev.Date)
+		}
+		if !rel.ValidUntil.IsZero() && !prev.ValidUntil.IsZero() && rel.ValidUntil.Before(prev.ValidUntil) {
+			return fmt.Errorf("refusing Release for %s: Valid-Until %s is older than previously trusted %s (possible downgrade attack)", sourceKey, rel.ValidUntil, prev.ValidUntil)
+		}
+	}
+
+	records[sourceKey] = trustedRelease{SourceKey: sourceKey, Fingerprint: fingerprint, Date: rel.Date, ValidUntil: rel.ValidUntil}
+	return t.saveTrustedReleases(records)
+}
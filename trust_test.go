@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// newTestKey generates a small (fast to create) RSA entity for signing test
+// fixtures, and writes its armored public key to dir/name.
+func newTestKey(t *testing.T, dir, name string) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test Key", "", "test@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatalf("NewEntity() error = %v", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer f.Close()
+
+	w, err := armor.Encode(f, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor.Encode() error = %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() error = %v", err)
+	}
+	return entity
+}
+
+func detachSign(t *testing.T, signer *openpgp.Entity, message []byte) []byte {
+	t.Helper()
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, signer, bytes.NewReader(message), nil); err != nil {
+		t.Fatalf("ArmoredDetachSign() error = %v", err)
+	}
+	return sig.Bytes()
+}
+
+func TestKeyringForScopesToSignedBy(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewTrustStore(dir)
+	if err != nil {
+		t.Fatalf("NewTrustStore() error = %v", err)
+	}
+
+	keyA := newTestKey(t, store.KeysDir, "a.asc")
+	newTestKey(t, store.KeysDir, "b.asc")
+
+	release := []byte("Suite: stable\n")
+	sigA := detachSign(t, keyA, release)
+
+	withoutSignedBy := &AptSource{URI: "http://example.com", Suite: "stable"}
+	keyring, err := store.KeyringFor(withoutSignedBy)
+	if err != nil {
+		t.Fatalf("KeyringFor() error = %v", err)
+	}
+	if len(keyring) != 2 {
+		t.Fatalf("KeyringFor(no signed-by) = %d keys, want 2 (whole pool)", len(keyring))
+	}
+	if _, err := store.VerifyRelease(keyring, release, sigA); err != nil {
+		t.Errorf("VerifyRelease() with whole pool error = %v", err)
+	}
+
+	scopedToB := &AptSource{URI: "http://example.com", Suite: "stable", SignedBy: "b.asc"}
+	keyring, err = store.KeyringFor(scopedToB)
+	if err != nil {
+		t.Fatalf("KeyringFor() error = %v", err)
+	}
+	if len(keyring) != 1 {
+		t.Fatalf("KeyringFor(signed-by b.asc) = %d keys, want 1", len(keyring))
+	}
+	if _, err := store.VerifyRelease(keyring, release, sigA); err == nil {
+		t.Errorf("VerifyRelease() signed by a.asc unexpectedly succeeded against a keyring scoped to b.asc")
+	}
+}
+
+func TestCheckAndRecordReleaseRejectsDowngrade(t *testing.T) {
+	store, err := NewTrustStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTrustStore() error = %v", err)
+	}
+	key := newTestKey(t, store.KeysDir, "key.asc")
+
+	newer := &ReleaseInfo{Date: time.Now()}
+	if err := store.CheckAndRecordRelease("src", newer, key); err != nil {
+		t.Fatalf("CheckAndRecordRelease() first call error = %v", err)
+	}
+
+	older := &ReleaseInfo{Date: newer.Date.Add(-1 * time.Hour)}
+	if err := store.CheckAndRecordRelease("src", older, key); err == nil {
+		t.Error("CheckAndRecordRelease() with an older Date unexpectedly succeeded")
+	}
+}
+
+func TestCheckAndRecordReleaseRejectsExpired(t *testing.T) {
+	store, err := NewTrustStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTrustStore() error = %v", err)
+	}
+	key := newTestKey(t, store.KeysDir, "key.asc")
+
+	expired := &ReleaseInfo{Date: time.Now(), ValidUntil: time.Now().Add(-1 * time.Hour)}
+	if err := store.CheckAndRecordRelease("src", expired, key); err == nil {
+		t.Error("CheckAndRecordRelease() with a past Valid-Until unexpectedly succeeded")
+	}
+}
+
+func TestCheckAndRecordReleaseRejectsValidUntilDowngrade(t *testing.T) {
+	store, err := NewTrustStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTrustStore() error = %v", err)
+	}
+	key := newTestKey(t, store.KeysDir, "key.asc")
+
+	now := time.Now()
+	first := &ReleaseInfo{Date: now, ValidUntil: now.Add(48 * time.Hour)}
+	if err := store.CheckAndRecordRelease("src", first, key); err != nil {
+		t.Fatalf("CheckAndRecordRelease() first call error = %v", err)
+	}
+
+	// A later Release with the same Date but a rolled-back Valid-Until
+	// would let an attacker shrink the freshness window; it must be
+	// rejected even though Date itself didn't go backwards.
+	rollback := &ReleaseInfo{Date: now, ValidUntil: now.Add(24 * time.Hour)}
+	if err := store.CheckAndRecordRelease("src", rollback, key); err == nil {
+		t.Error("CheckAndRecordRelease() with an older Valid-Until unexpectedly succeeded")
+	}
+}